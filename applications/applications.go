@@ -0,0 +1,86 @@
+// Package applications persists the lifecycle of an asynchronous loan
+// application: submitted with a callback URL, decided in the background,
+// and delivered (with retries) to that URL, or poll-able via GET
+// /applications/{id} in the meantime. There's no vendored BoltDB or
+// Postgres driver in this tree, so Store has an in-memory implementation
+// for a single instance and a JSON-file implementation that's actually
+// durable across restarts, mirroring how idempotency.Store has a Redis
+// implementation hand-rolled over raw RESP rather than a pulled-in client.
+package applications
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// Status is where an asynchronous application is in its lifecycle.
+type Status string
+
+const (
+	// StatusPending means the decision hasn't been made yet.
+	StatusPending Status = "pending"
+	// StatusProcessing means a decision has been made (Response is set) and
+	// delivery to CallbackURL is in progress or awaiting retry.
+	StatusProcessing Status = "processing"
+	// StatusDelivered means the callback POST succeeded.
+	StatusDelivered Status = "delivered"
+	// StatusDeadLetter means delivery exhausted its retry budget.
+	StatusDeadLetter Status = "dead_letter"
+)
+
+// ErrNotFound is returned by Get for an unknown application ID.
+var ErrNotFound = errors.New("applications: not found")
+
+// Application is one asynchronous /apply-loan submission. Request and
+// Response are kept as raw JSON so this package doesn't need to import the
+// main package's types.
+type Application struct {
+	ID          string
+	NationalID  string
+	CallbackURL string
+	Request     []byte
+	Response    []byte // set once decided
+	Status      Status
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Store persists Applications and lets the delivery dispatcher find the
+// ones due for a delivery attempt. Implementations must make Update safe to
+// call concurrently with DueForDelivery, and DueForDelivery itself must
+// atomically claim what it returns (see its doc comment) so the same
+// application is never handed to two dispatch attempts at once.
+type Store interface {
+	Create(app *Application) error
+	Get(id string) (*Application, error)
+	Update(app *Application) error
+	// DueForDelivery finds applications in StatusProcessing whose
+	// NextAttempt is at or before now and atomically claims each one by
+	// advancing its stored NextAttempt to now+lease before returning it -
+	// all under the same lock/transaction, so a concurrent or slow-to-notice
+	// caller can't also claim it before the lease expires. The caller is
+	// expected to finish the attempt (and persist a new Status/NextAttempt
+	// via Update) well within lease; if it doesn't (e.g. it crashed), the
+	// application becomes due again once the lease elapses.
+	DueForDelivery(now time.Time, lease time.Duration) ([]*Application, error)
+}
+
+// NewStoreFromEnv builds a Store based on APPLICATIONS_STORE ("memory", the
+// default, or "file"). APPLICATIONS_DIR configures the file store's
+// directory (default "./data/applications").
+func NewStoreFromEnv() (Store, error) {
+	switch os.Getenv("APPLICATIONS_STORE") {
+	case "file":
+		dir := os.Getenv("APPLICATIONS_DIR")
+		if dir == "" {
+			dir = "./data/applications"
+		}
+		return NewFileStore(dir)
+	default:
+		return NewMemoryStore(), nil
+	}
+}
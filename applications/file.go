@@ -0,0 +1,109 @@
+package applications
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore persists one JSON file per application under dir, so
+// applications and their delivery progress survive a restart without
+// needing a real embedded-KV or SQL driver vendored into this tree. It's
+// intended for a single instance; a Postgres-backed Store would be the
+// natural next step if this needs to run behind a load balancer.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStore returns a FileStore rooted at dir, creating it if necessary.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("applications: create store dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *FileStore) Create(app *Application) error {
+	return s.write(app)
+}
+
+func (s *FileStore) Get(id string) (*Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(id)
+}
+
+func (s *FileStore) Update(app *Application) error {
+	return s.write(app)
+}
+
+func (s *FileStore) DueForDelivery(now time.Time, lease time.Duration) ([]*Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("applications: list store dir: %w", err)
+	}
+	var due []*Application
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		id := entry.Name()[:len(entry.Name())-len(".json")]
+		app, err := s.read(id)
+		if err != nil {
+			continue
+		}
+		if app.Status == StatusProcessing && !app.NextAttempt.After(now) {
+			app.NextAttempt = now.Add(lease)
+			if err := s.writeLocked(app); err != nil {
+				continue
+			}
+			due = append(due, app)
+		}
+	}
+	return due, nil
+}
+
+func (s *FileStore) write(app *Application) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(app)
+}
+
+// writeLocked does the actual write; callers must hold s.mu.
+func (s *FileStore) writeLocked(app *Application) error {
+	data, err := json.Marshal(app)
+	if err != nil {
+		return fmt.Errorf("applications: marshal: %w", err)
+	}
+	tmp := s.path(app.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("applications: write: %w", err)
+	}
+	return os.Rename(tmp, s.path(app.ID))
+}
+
+func (s *FileStore) read(id string) (*Application, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("applications: read: %w", err)
+	}
+	var app Application
+	if err := json.Unmarshal(data, &app); err != nil {
+		return nil, fmt.Errorf("applications: unmarshal: %w", err)
+	}
+	return &app, nil
+}
@@ -0,0 +1,62 @@
+package applications
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store, suitable for a single instance or for
+// tests. State (and any undelivered webhooks) is lost on restart.
+type MemoryStore struct {
+	mu   sync.Mutex
+	apps map[string]*Application
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{apps: make(map[string]*Application)}
+}
+
+func (s *MemoryStore) Create(app *Application) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *app
+	s.apps[app.ID] = &cp
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.apps[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *app
+	return &cp, nil
+}
+
+func (s *MemoryStore) Update(app *Application) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.apps[app.ID]; !ok {
+		return ErrNotFound
+	}
+	cp := *app
+	s.apps[app.ID] = &cp
+	return nil
+}
+
+func (s *MemoryStore) DueForDelivery(now time.Time, lease time.Duration) ([]*Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*Application
+	for _, app := range s.apps {
+		if app.Status == StatusProcessing && !app.NextAttempt.After(now) {
+			app.NextAttempt = now.Add(lease)
+			cp := *app
+			due = append(due, &cp)
+		}
+	}
+	return due, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultBatchConcurrency is used when BATCH_CONCURRENCY is unset or not a
+// positive integer.
+const defaultBatchConcurrency = 8
+
+// batchConcurrencyFromEnv reads BATCH_CONCURRENCY, falling back to
+// defaultBatchConcurrency.
+func batchConcurrencyFromEnv() int {
+	if v := os.Getenv("BATCH_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultBatchConcurrency
+}
+
+// batchHandler implements /apply-loan/batch: an NDJSON stream of
+// LoanRequests in, an NDJSON stream of LoanResponses out, one per input
+// line, flushed as each is decided, followed by a summary line. It runs
+// every line through the same decideLoan pipeline as loanHandler, fanned
+// out across a bounded worker pool, and shares one lookupCache across the
+// whole batch so repeated national IDs only hit salary/credit once.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	salaryURL := os.Getenv("SALARY_API_URL")
+	creditURL := os.Getenv("CREDIT_API_URL")
+	if salaryURL == "" || creditURL == "" {
+		http.Error(w, "Service URLs not configured", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	cache := newLookupCache()
+	concurrency := batchConcurrencyFromEnv()
+
+	lines := make(chan []byte)
+	results := make(chan LoanResponse)
+
+	var workers sync.WaitGroup
+	workers.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer workers.Done()
+			for line := range lines {
+				resp := decideBatchLine(ctx, line, salaryURL, creditURL, cache)
+				select {
+				case results <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r.Body)
+		// LoanRequest lines are small, but give headroom beyond the default
+		// 64KiB token limit for oddly-formatted uploads.
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			cp := make([]byte, len(line))
+			copy(cp, line)
+			select {
+			case lines <- cp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	var approved, declined, errored int
+	for resp := range results {
+		switch resp.Status {
+		case "APPROVED":
+			approved++
+		case "DECLINED":
+			declined++
+		default:
+			errored++
+		}
+		if err := enc.Encode(resp); err != nil {
+			// Client disconnected; nothing left to stream to. The reader and
+			// worker goroutines drain on their own once ctx is cancelled.
+			return
+		}
+		flusher.Flush()
+	}
+
+	_ = enc.Encode(map[string]map[string]int{
+		"summary": {"approved": approved, "declined": declined, "errors": errored},
+	})
+	flusher.Flush()
+}
+
+// decideBatchLine decodes and decides a single NDJSON line, turning decode
+// failures, validation failures, and upstream errors alike into a
+// LoanResponse with Status "ERROR" so the output stream stays one
+// LoanResponse per line.
+func decideBatchLine(ctx context.Context, line []byte, salaryURL, creditURL string, cache *lookupCache) LoanResponse {
+	var request LoanRequest
+	if err := json.Unmarshal(line, &request); err != nil {
+		return LoanResponse{Status: "ERROR", Reason: "invalid JSON", Error: err.Error()}
+	}
+	if msg := validateLoanRequest(request); msg != "" {
+		return LoanResponse{Status: "ERROR", Reason: msg, ApplicationEcho: &request}
+	}
+
+	reqID := request.NationalID + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	outcome := decideLoan(ctx, request, reqID, salaryURL, creditURL, cache)
+	if outcome.StatusCode != http.StatusOK {
+		msg := outcome.ErrKind
+		if outcome.Err != nil {
+			msg = outcome.Err.Error()
+		}
+		return LoanResponse{Status: "ERROR", Reason: outcome.ErrKind, ApplicationEcho: &request, Error: msg}
+	}
+	return outcome.Response
+}
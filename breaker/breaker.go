@@ -0,0 +1,152 @@
+// Package breaker implements a small per-dependency circuit breaker keyed by
+// base URL. It protects upstream calls (salary, credit, ...) from being
+// hammered once a dependency starts failing consistently.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by Breaker.Allow when the breaker is open and
+// calls are being short-circuited.
+var ErrBreakerOpen = errors.New("breaker: circuit open")
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// Breaker is a closed -> open -> half-open -> closed state machine for a
+// single upstream dependency. It trips open after Threshold consecutive
+// failures, stays open for Cooldown, then allows a single probe request
+// through in the half-open state.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu              sync.Mutex
+	state           state
+	consecutiveFail int
+	openedAt        time.Time
+	halfOpenInUse   bool
+}
+
+// New returns a Breaker that opens after threshold consecutive failures and
+// probes again after cooldown.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// Allow reports whether a call should proceed. It returns ErrBreakerOpen if
+// the circuit is open and the cooldown has not yet elapsed.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case closed:
+		return nil
+	case open:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return ErrBreakerOpen
+		}
+		// Cooldown elapsed: allow a single probe through.
+		b.state = halfOpen
+		b.halfOpenInUse = true
+		return nil
+	case halfOpen:
+		if b.halfOpenInUse {
+			return ErrBreakerOpen
+		}
+		b.halfOpenInUse = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the breaker and resets the failure count.
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = closed
+	b.consecutiveFail = 0
+	b.halfOpenInUse = false
+}
+
+// RecordFailure registers a failed call, tripping the breaker open once
+// Threshold consecutive failures have been observed (or immediately if the
+// failure happened during a half-open probe).
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == halfOpen {
+		b.trip()
+		return
+	}
+
+	b.consecutiveFail++
+	if b.consecutiveFail >= b.Threshold {
+		b.trip()
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state = open
+	b.openedAt = time.Now()
+	b.halfOpenInUse = false
+}
+
+// RetryAfter returns how long a caller should wait before the breaker is
+// expected to allow calls again.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != open {
+		return 0
+	}
+	remaining := b.Cooldown - time.Since(b.openedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Registry hands out one Breaker per key (typically an upstream base URL),
+// creating it lazily on first use.
+type Registry struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry returns a Registry whose breakers all share the same
+// threshold/cooldown configuration.
+func NewRegistry(threshold int, cooldown time.Duration) *Registry {
+	return &Registry{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*Breaker),
+	}
+}
+
+// Get returns the Breaker for key, creating it if this is the first call for
+// that key.
+func (r *Registry) Get(key string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok {
+		b = New(r.threshold, r.cooldown)
+		r.breakers[key] = b
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTripsAfterThreshold(t *testing.T) {
+	b := New(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow() = %v, want nil before threshold", err)
+		}
+		b.RecordFailure()
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil with 2 failures and threshold 3", err)
+	}
+	b.RecordFailure()
+
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("Allow() = %v, want ErrBreakerOpen after 3 consecutive failures", err)
+	}
+}
+
+func TestBreakerSuccessResetsFailureCount(t *testing.T) {
+	b := New(3, time.Minute)
+
+	b.Allow()
+	b.RecordFailure()
+	b.Allow()
+	b.RecordFailure()
+	b.RecordSuccess()
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow() = %v, want nil after RecordSuccess reset the count", err)
+		}
+		b.RecordFailure()
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want still closed (only 2 failures since reset)", err)
+	}
+}
+
+func TestBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	b := New(1, 10*time.Millisecond)
+
+	b.Allow()
+	b.RecordFailure()
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("Allow() = %v, want ErrBreakerOpen immediately after tripping", err)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil for the half-open probe after cooldown", err)
+	}
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("Allow() = %v, want ErrBreakerOpen for a second concurrent half-open caller", err)
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b := New(1, 10*time.Millisecond)
+
+	b.Allow()
+	b.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil for the half-open probe", err)
+	}
+	b.RecordFailure()
+
+	if err := b.Allow(); err != ErrBreakerOpen {
+		t.Fatalf("Allow() = %v, want ErrBreakerOpen: a failed probe must reopen the circuit", err)
+	}
+}
+
+func TestBreakerHalfOpenSuccessCloses(t *testing.T) {
+	b := New(2, 10*time.Millisecond)
+
+	b.Allow()
+	b.RecordFailure()
+	b.Allow()
+	b.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+
+	b.Allow()
+	b.RecordSuccess()
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil: a successful probe must close the circuit", err)
+	}
+	b.RecordFailure()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil: failure count should have reset on close (only 1 failure since)", err)
+	}
+}
+
+func TestRegistryReusesBreakerPerKey(t *testing.T) {
+	r := NewRegistry(1, time.Minute)
+
+	a := r.Get("https://salary")
+	b := r.Get("https://salary")
+	if a != b {
+		t.Fatal("Get() returned different Breakers for the same key")
+	}
+
+	c := r.Get("https://credit")
+	if a == c {
+		t.Fatal("Get() returned the same Breaker for different keys")
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Thoriso27/loan-eligibility-api/applications"
+	"github.com/Thoriso27/loan-eligibility-api/webhookclient"
+)
+
+// applicationStore backs the async (callback_url) submission path and the
+// GET /applications/{id} poll endpoint. Set in main() from
+// APPLICATIONS_STORE/APPLICATIONS_DIR; read-only afterwards.
+var applicationStore applications.Store
+
+// maxWebhookAttempts bounds how many times a callback delivery is retried
+// before the application is dead-lettered.
+const maxWebhookAttempts = 6
+
+// webhookBackoffBase/Max shape the delay between delivery attempts; reusing
+// the same full-jitter strategy as the upstream retry policy in policy.go.
+const webhookBackoffBase = 1 * time.Second
+const webhookBackoffMax = 5 * time.Minute
+
+// webhookLeaseDuration bounds how long an application may sit "claimed" for
+// delivery (NextAttempt pushed into the future by this much) before the
+// dispatcher will consider it due again. It's kept comfortably above the
+// callback HTTP client's own timeout so a normal in-flight attempt is never
+// re-claimed and double-delivered; only a crashed/stuck attempt reaches it.
+const webhookLeaseDuration = 30 * time.Second
+
+// webhookSecret resolves the HMAC key used to sign callback deliveries. This
+// service has no per-tenant auth concept yet, so every callback is signed
+// with the single secret in WEBHOOK_SECRET; a real multi-tenant deployment
+// would look this up from whatever identifies the caller.
+func webhookSecret() string {
+	return os.Getenv("WEBHOOK_SECRET")
+}
+
+// newApplicationID generates the opaque ID returned to callers of the async
+// path and used as the store key.
+func newApplicationID() string {
+	return "app_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// acceptAsync handles a /apply-loan submission that supplied a callback_url:
+// it records the application as pending, returns 202 immediately, and
+// decides + delivers it on a background goroutine.
+func acceptAsync(w http.ResponseWriter, request LoanRequest, reqID, salaryURL, creditURL string) {
+	rawRequest, _ := json.Marshal(request)
+	now := time.Now()
+	app := &applications.Application{
+		ID:          newApplicationID(),
+		NationalID:  request.NationalID,
+		CallbackURL: request.CallbackURL,
+		Request:     rawRequest,
+		Status:      applications.StatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := applicationStore.Create(app); err != nil {
+		http.Error(w, "failed to accept application", http.StatusInternalServerError)
+		return
+	}
+
+	go decideAndDeliver(app.ID, request, reqID, salaryURL, creditURL)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"application_id": app.ID,
+		"status":         string(applications.StatusPending),
+	})
+}
+
+// decideAndDeliver runs the decision pipeline for an accepted async
+// application, stores the result, and makes the first delivery attempt. It
+// runs detached from the original request, so it uses its own background
+// context rather than the (already-returned) request's.
+func decideAndDeliver(appID string, request LoanRequest, reqID, salaryURL, creditURL string) {
+	outcome := decideLoan(context.Background(), request, reqID, salaryURL, creditURL, newLookupCache())
+
+	response := outcome.Response
+	response.ApplicationID = appID
+	if outcome.StatusCode != http.StatusOK {
+		response.Status = "ERROR"
+		response.Reason = outcome.ErrKind
+		if outcome.Err != nil {
+			response.Error = outcome.Err.Error()
+		}
+	}
+	body, _ := json.Marshal(response)
+
+	app, err := applicationStore.Get(appID)
+	if err != nil {
+		log.Printf("req_id=%s application=%s lost before delivery: %v", reqID, appID, err)
+		return
+	}
+	app.Response = body
+	app.Status = applications.StatusProcessing
+	// Lease it to this goroutine's own in-flight attempt below, the same way
+	// DueForDelivery leases a claim to the dispatcher: otherwise the
+	// dispatcher's next tick would see NextAttempt as already due and
+	// re-deliver the same callback while this attempt is still in flight.
+	app.NextAttempt = time.Now().Add(webhookLeaseDuration)
+	app.UpdatedAt = time.Now()
+	if err := applicationStore.Update(app); err != nil {
+		log.Printf("req_id=%s application=%s failed to store decision: %v", reqID, appID, err)
+		return
+	}
+
+	attemptDelivery(app)
+}
+
+// attemptDelivery POSTs app.Response to app.CallbackURL, signed per
+// webhookclient's scheme, and advances the application's status: Delivered
+// on a 2xx response, DeadLetter once maxWebhookAttempts is exhausted, or
+// Processing with a backed-off NextAttempt so the dispatcher retries it.
+func attemptDelivery(app *applications.Application) {
+	ts := time.Now().Unix()
+	sig := webhookclient.Sign(webhookSecret(), ts, app.Response)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	app.Attempts++
+	app.UpdatedAt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.CallbackURL, bytes.NewReader(app.Response))
+	if err != nil {
+		// CallbackURL is validated at submission time, so this should be
+		// unreachable in practice; dead-letter immediately rather than
+		// retrying a request that can never be built.
+		app.Status = applications.StatusDeadLetter
+		app.LastError = "building callback request: " + err.Error()
+		log.Printf("application=%s webhook delivery dead-lettered: %s", app.ID, app.LastError)
+		if err := applicationStore.Update(app); err != nil {
+			log.Printf("application=%s failed to record delivery attempt: %v", app.ID, err)
+		}
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", sig)
+
+	res, err := httpClient.Do(req)
+	if err == nil {
+		defer res.Body.Close()
+	}
+	delivered := err == nil && res.StatusCode >= 200 && res.StatusCode < 300
+
+	switch {
+	case delivered:
+		app.Status = applications.StatusDelivered
+		app.LastError = ""
+	case app.Attempts >= maxWebhookAttempts:
+		app.Status = applications.StatusDeadLetter
+		app.LastError = deliveryErrorMessage(err, res)
+		log.Printf("application=%s webhook delivery dead-lettered after %d attempts: %s", app.ID, app.Attempts, app.LastError)
+	default:
+		app.LastError = deliveryErrorMessage(err, res)
+		app.NextAttempt = time.Now().Add(fullJitterBackoff(webhookBackoffBase, webhookBackoffMax, app.Attempts-1))
+	}
+
+	if err := applicationStore.Update(app); err != nil {
+		log.Printf("application=%s failed to record delivery attempt: %v", app.ID, err)
+	}
+}
+
+func deliveryErrorMessage(err error, res *http.Response) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "callback returned " + strconv.Itoa(res.StatusCode)
+}
+
+// runWebhookDispatcher polls applicationStore for deliveries that are due
+// (first attempt already happens inline in decideAndDeliver; this loop only
+// picks up retries) and retries each of them, until ctx is cancelled.
+func runWebhookDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			due, err := applicationStore.DueForDelivery(time.Now(), webhookLeaseDuration)
+			if err != nil {
+				log.Printf("webhook dispatcher: list due deliveries: %v", err)
+				continue
+			}
+			for _, app := range due {
+				go attemptDelivery(app)
+			}
+		}
+	}
+}
+
+// applicationStatusHandler implements GET /applications/{id}, letting a
+// caller poll for the outcome of an async submission instead of (or in
+// addition to) waiting for the callback.
+func applicationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/applications/")
+	if id == "" {
+		http.Error(w, "missing application id", http.StatusBadRequest)
+		return
+	}
+
+	app, err := applicationStore.Get(id)
+	if err != nil {
+		http.Error(w, "application not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if app.Status == applications.StatusPending || (app.Status == applications.StatusProcessing && app.Response == nil) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"application_id": app.ID,
+			"status":         string(app.Status),
+		})
+		return
+	}
+
+	// Once a decision exists, app.Response is already the full LoanResponse
+	// JSON (including application_id); echo it as-is plus the delivery status.
+	var resp json.RawMessage = app.Response
+	_ = json.NewEncoder(w).Encode(struct {
+		ApplicationID string          `json:"application_id"`
+		Status        string          `json:"status"`
+		LastError     string          `json:"last_error,omitempty"`
+		Response      json.RawMessage `json:"response"`
+	}{
+		ApplicationID: app.ID,
+		Status:        string(app.Status),
+		LastError:     app.LastError,
+		Response:      resp,
+	})
+}
@@ -9,6 +9,9 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/Thoriso27/loan-eligibility-api/idempotency"
+	"github.com/Thoriso27/loan-eligibility-api/observability"
 )
 
 type CreditRequest struct {
@@ -43,6 +46,16 @@ var creditData = map[string]CreditResponse{
 	},
 }
 
+// creditRequestNationalID is passed to idempotency.Middleware so the stored
+// hash also covers the applicant identity, not just the raw bytes.
+func creditRequestNationalID(body []byte) string {
+	var req CreditRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.NationalID
+}
+
 func checkCreditHandler(w http.ResponseWriter, r *http.Request) {
 	reqID := r.Header.Get("X-Request-ID")
 	if reqID != "" {
@@ -83,8 +96,14 @@ func checkCreditHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	metrics := observability.NewMetrics("credit")
+	tracer := observability.NewTracer("credit")
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/check-credit", checkCreditHandler)
+	store := idempotency.NewStoreFromEnv("credit")
+	mux.HandleFunc("/check-credit", metrics.Middleware("/check-credit",
+		tracer.Middleware("/check-credit", idempotency.Middleware(store, creditRequestNationalID, checkCreditHandler))))
+	mux.HandleFunc("/metrics", metrics.Handler())
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/Thoriso27/loan-eligibility-api/breaker"
+	"github.com/Thoriso27/loan-eligibility-api/rules"
+)
+
+// decisionOutcome is the result of running the decision pipeline for one
+// application. StatusCode is the HTTP status the caller should use; it is
+// only ever 200 (Response populated, approved or declined), 502 (an
+// upstream call failed for a reason other than the breaker being open), or
+// 503 (the breaker for BreakerDep is open). Callers that don't speak HTTP
+// status codes (e.g. the batch endpoint) can treat anything other than 200
+// as an error and fall back to Err/ErrKind for detail.
+type decisionOutcome struct {
+	Response   LoanResponse
+	StatusCode int
+	BreakerDep string // "salary" or "credit", the failing dependency; set when StatusCode is 502 or 503
+	ErrKind    string // short machine-readable reason, set when StatusCode != 200
+	Err        error  // underlying error, set when StatusCode != 200
+}
+
+// validateLoanRequest returns a human-readable problem description, or "" if
+// request is well-formed.
+func validateLoanRequest(request LoanRequest) string {
+	if request.NationalID == "" || request.LoanAmount <= 0 || request.TermMonths <= 0 {
+		return "Missing or invalid fields"
+	}
+	if request.CallbackURL != "" {
+		parsed, err := url.Parse(request.CallbackURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "callback_url must be a valid http(s) URL"
+		}
+	}
+	return ""
+}
+
+// decideLoan runs the full eligibility pipeline for one application: fan out
+// to salary/credit (via cache, so repeated national IDs within a batch only
+// hit the upstreams once), then evaluate the active scorecard. It is shared
+// by loanHandler and the /apply-loan/batch worker pool so both endpoints
+// make exactly the same decision for the same inputs.
+func decideLoan(ctx context.Context, request LoanRequest, reqID, salaryURL, creditURL string, cache *lookupCache) decisionOutcome {
+	// Provisional rate used only to derive a monthly payment for the
+	// salary-multiple rule; the scorecard's risk band picks the real rate.
+	annualRate := activeScorecard.BaseAnnualInterest()
+
+	// Salary and credit lookups are independent, so fan them out
+	// concurrently and join on both. cache de-dupes repeated national IDs
+	// within a batch; for a single request it's just a pass-through.
+	var salary SalaryResponse
+	var credit CreditResponse
+	var salaryErr, creditErr error
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		salary, salaryErr = cache.salaryFor(ctx, request.NationalID, salaryURL, reqID)
+	}()
+	go func() {
+		defer wg.Done()
+		credit, creditErr = cache.creditFor(ctx, request.NationalID, creditURL, reqID)
+	}()
+	wg.Wait()
+
+	if salaryErr != nil {
+		if errors.Is(salaryErr, breaker.ErrBreakerOpen) {
+			return decisionOutcome{StatusCode: http.StatusServiceUnavailable, BreakerDep: "salary", ErrKind: "breaker_open", Err: salaryErr}
+		}
+		if httpErr, ok := salaryErr.(*httpError); ok && httpErr.StatusCode == http.StatusNotFound {
+			monthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, annualRate)
+			reasons := []string{"Salary record not found"}
+			metrics.RecordDecision("declined", "salary_not_found")
+			return decisionOutcome{StatusCode: http.StatusOK, Response: LoanResponse{
+				Status:          "DECLINED",
+				Reason:          reasons[0],
+				Reasons:         reasons,
+				MonthlyPayment:  monthly,
+				AnnualInterest:  annualRate,
+				ApplicationEcho: &request,
+			}}
+		}
+		return decisionOutcome{StatusCode: http.StatusBadGateway, BreakerDep: "salary", ErrKind: "salary_service_unavailable", Err: salaryErr}
+	}
+
+	if creditErr != nil {
+		if errors.Is(creditErr, breaker.ErrBreakerOpen) {
+			return decisionOutcome{StatusCode: http.StatusServiceUnavailable, BreakerDep: "credit", ErrKind: "breaker_open", Err: creditErr}
+		}
+		if httpErr, ok := creditErr.(*httpError); ok && httpErr.StatusCode == http.StatusNotFound {
+			monthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, annualRate)
+			reasons := []string{"Credit record not found"}
+			metrics.RecordDecision("declined", "credit_not_found")
+			return decisionOutcome{StatusCode: http.StatusOK, Response: LoanResponse{
+				Status:          "DECLINED",
+				Reason:          reasons[0],
+				Reasons:         reasons,
+				MonthlyPayment:  monthly,
+				AnnualInterest:  annualRate,
+				SalaryEcho:      &salary,
+				ApplicationEcho: &request,
+			}}
+		}
+		return decisionOutcome{StatusCode: http.StatusBadGateway, BreakerDep: "credit", ErrKind: "credit_service_unavailable", Err: creditErr}
+	}
+
+	// Decision rules, via the scorecard. The provisional monthly payment
+	// (computed at the base rate) feeds DerivedMonthlyPayment for the
+	// salary-multiple rule; once the risk band is known we recompute the
+	// monthly payment at the band's actual rate.
+	provisionalMonthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, annualRate)
+	decision := activeScorecard.Evaluate(rules.ApplicantContext{
+		Salary: salary.MonthlySalary,
+		Credit: rules.CreditContext{
+			Score:          credit.CreditScore,
+			ActiveDefaults: credit.ActiveDefaults,
+			ActiveLoans:    credit.ActiveLoans,
+		},
+		Application: rules.ApplicationContext{
+			NationalID: request.NationalID,
+			LoanAmount: request.LoanAmount,
+			TermMonths: request.TermMonths,
+		},
+		DerivedMonthlyPayment: provisionalMonthly,
+	})
+	monthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, decision.AnnualInterest)
+
+	reasons := make([]string, 0, len(decision.FiredRules))
+	for _, fired := range decision.FiredRules {
+		reasons = append(reasons, fired.Message)
+	}
+
+	if !decision.Approved {
+		log.Printf("req_id=%s declined id=%s reasons=%v monthly=%v", reqID, request.NationalID, reasons, monthly)
+		metrics.RecordDecision("declined", decision.FiredRules[0].ID)
+		return decisionOutcome{StatusCode: http.StatusOK, Response: LoanResponse{
+			Status:          "DECLINED",
+			Reason:          reasons[0],
+			Reasons:         reasons,
+			MonthlyPayment:  monthly,
+			AnnualInterest:  decision.AnnualInterest,
+			SalaryEcho:      &salary,
+			CreditEcho:      &credit,
+			ApplicationEcho: &request,
+			Decision:        &decision,
+		}}
+	}
+
+	log.Printf("req_id=%s approved id=%s monthly=%v risk_tier=%s", reqID, request.NationalID, monthly, decision.RiskTier)
+	metrics.RecordDecision("approved", decision.RiskTier)
+	return decisionOutcome{StatusCode: http.StatusOK, Response: LoanResponse{
+		Status:          "APPROVED",
+		MonthlyPayment:  monthly,
+		AnnualInterest:  decision.AnnualInterest,
+		SalaryEcho:      &salary,
+		CreditEcho:      &credit,
+		ApplicationEcho: &request,
+		Decision:        &decision,
+	}}
+}
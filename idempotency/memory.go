@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+type entry struct {
+	bodyHash  string
+	record    *Record // nil while in progress
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store, suitable for a single instance or for
+// tests. State is lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry)}
+}
+
+func (s *MemoryStore) Reserve(key, bodyHash string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && time.Now().Before(e.expiresAt) {
+		if e.bodyHash != bodyHash {
+			return nil, ErrKeyMismatch
+		}
+		if e.record == nil {
+			return nil, ErrInProgress
+		}
+		return e.record, nil
+	}
+
+	s.entries[key] = &entry{bodyHash: bodyHash, expiresAt: time.Now().Add(ttl)}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(key string, rec Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := rec
+	s.entries[key] = &entry{bodyHash: rec.BodyHash, record: &r, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,172 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HeaderKey is the request header clients set to make a request idempotent.
+const HeaderKey = "Idempotency-Key"
+
+// DefaultTTL is how long a completed record is honoured before the key can
+// be reused for a new request.
+const DefaultTTL = 24 * time.Hour
+
+// ReserveLeaseTTL bounds how long an in-progress reservation blocks retries
+// with a 409 before it's treated as abandoned (the handler panicked or the
+// process died mid-request) and the key becomes reservable again. It's kept
+// far shorter than DefaultTTL, which only applies once a terminal outcome
+// has actually been cached.
+const ReserveLeaseTTL = 30 * time.Second
+
+// NewStoreFromEnv builds a Store based on IDEMPOTENCY_STORE ("memory", the
+// default, or "redis"). REDIS_ADDR configures the Redis store's address
+// (default "localhost:6379"). service namespaces the store's keys (e.g.
+// "eligibility", "salary", "credit") so a client-chosen Idempotency-Key
+// can't collide across services sharing the same Redis instance.
+func NewStoreFromEnv(service string) Store {
+	switch os.Getenv("IDEMPOTENCY_STORE") {
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return NewRedisStore(addr, 2*time.Second, service)
+	default:
+		return NewMemoryStore()
+	}
+}
+
+// Middleware wraps next so that, when the caller supplies an Idempotency-Key
+// header, the first response for that key is stored and byte-for-byte
+// replayed on retry. A 5xx response is not stored: the key is released so a
+// retry runs the handler again. Requests without the header pass through
+// unchanged.
+// nationalID extracts the applicant identifier from the decoded body (used
+// only to fold into the hash alongside the raw bytes); it may be nil if the
+// endpoint has no such concept.
+func Middleware(store Store, nationalID func(body []byte) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := bodyHash(body, nationalID)
+
+		existing, err := store.Reserve(key, hash, ReserveLeaseTTL)
+		if err != nil {
+			switch err {
+			case ErrKeyMismatch:
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+					"error":   "idempotency_key_reused",
+					"message": "Idempotency-Key was previously used with a different request body",
+				})
+			case ErrInProgress:
+				writeJSON(w, http.StatusConflict, map[string]string{
+					"error":   "idempotency_in_progress",
+					"message": "a request with this Idempotency-Key is still being processed",
+				})
+			default:
+				http.Error(w, "idempotency store error: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		if existing != nil {
+			if existing.ContentType != "" {
+				w.Header().Set("Content-Type", existing.ContentType)
+			}
+			w.WriteHeader(existing.StatusCode)
+			_, _ = w.Write(existing.Body)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		func() {
+			// If the handler panics, the key must not stay reserved for the
+			// full lease: release it so a retry gets a fresh attempt instead
+			// of a wedged 409, then keep unwinding the panic.
+			defer func() {
+				if p := recover(); p != nil {
+					_ = store.Release(key)
+					panic(p)
+				}
+			}()
+			next(rec, r)
+		}()
+
+		if rec.status >= http.StatusInternalServerError {
+			// Transient infrastructure failure (breaker-open, upstream
+			// unavailable, ...): don't cache it, or a retry of exactly the
+			// failure this feature exists to smooth over would keep getting
+			// the same stale error replayed for DefaultTTL.
+			_ = store.Release(key)
+			return
+		}
+
+		if err := store.Complete(key, Record{
+			BodyHash:    hash,
+			StatusCode:  rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}, DefaultTTL); err != nil {
+			_ = store.Release(key)
+		}
+	}
+}
+
+func bodyHash(body []byte, nationalID func([]byte) string) string {
+	h := sha256.New()
+	h.Write(body)
+	if nationalID != nil {
+		h.Write([]byte(nationalID(body)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// responseRecorder captures a handler's status code and body so they can be
+// both sent to the real client and stashed in the idempotency store.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,91 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RedisStore persists idempotency records in Redis so they're shared across
+// instances and survive restarts. It talks RESP directly (see resp.go)
+// rather than pulling in a client library.
+type RedisStore struct {
+	conn      *respConn
+	keyPrefix string
+}
+
+// NewRedisStore returns a RedisStore talking to the Redis instance at addr
+// (host:port). Each command opens its own connection with timeout as the
+// dial/IO deadline. service is folded into every key so that services
+// sharing one Redis instance can't have a client-chosen Idempotency-Key
+// collide across unrelated routes.
+func NewRedisStore(addr string, timeout time.Duration, service string) *RedisStore {
+	return &RedisStore{conn: newRESPConn(addr, timeout), keyPrefix: "idempotency:" + service + ":"}
+}
+
+func (s *RedisStore) lockKey(key string) string   { return s.keyPrefix + "lock:" + key }
+func (s *RedisStore) recordKey(key string) string { return s.keyPrefix + "record:" + key }
+
+func (s *RedisStore) Reserve(key, bodyHash string, ttl time.Duration) (*Record, error) {
+	if existing, err := s.getRecord(key); err != nil {
+		return nil, err
+	} else if existing != nil {
+		if existing.BodyHash != bodyHash {
+			return nil, ErrKeyMismatch
+		}
+		return existing, nil
+	}
+
+	ttlMillis := fmt.Sprintf("%d", ttl.Milliseconds())
+	reply, err := s.conn.do("SET", s.lockKey(key), bodyHash, "NX", "PX", ttlMillis)
+	if err != nil {
+		return nil, err
+	}
+	if reply != nil {
+		// Lock acquired; caller should do the work and call Complete/Release.
+		return nil, nil
+	}
+
+	// Lock already held: distinguish "same key/body in flight" from "key
+	// reused with a different body" by checking what's under the lock.
+	held, err := s.conn.do("GET", s.lockKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if held != nil && *held != bodyHash {
+		return nil, ErrKeyMismatch
+	}
+	return nil, ErrInProgress
+}
+
+func (s *RedisStore) Complete(key string, rec Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("idempotency: marshal record: %w", err)
+	}
+	if _, err := s.conn.do("SET", s.recordKey(key), string(data), "PX", fmt.Sprintf("%d", ttl.Milliseconds())); err != nil {
+		return err
+	}
+	_, err = s.conn.do("DEL", s.lockKey(key))
+	return err
+}
+
+func (s *RedisStore) Release(key string) error {
+	_, err := s.conn.do("DEL", s.lockKey(key))
+	return err
+}
+
+func (s *RedisStore) getRecord(key string) (*Record, error) {
+	reply, err := s.conn.do("GET", s.recordKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, nil
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(*reply), &rec); err != nil {
+		return nil, fmt.Errorf("idempotency: unmarshal record: %w", err)
+	}
+	return &rec, nil
+}
@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// respConn is a minimal RESP (Redis Serialization Protocol) client. It only
+// implements the handful of commands RedisStore needs (SET/GET/DEL), since
+// this repo has no vendored Redis driver. It opens a fresh connection per
+// command, which is fine for the request volumes this service handles; a
+// pooled connection would be the first thing to add if that changes.
+type respConn struct {
+	addr    string
+	timeout time.Duration
+}
+
+func newRESPConn(addr string, timeout time.Duration) *respConn {
+	return &respConn{addr: addr, timeout: timeout}
+}
+
+// do sends a command as a RESP array of bulk strings and returns the raw
+// reply: a *string for bulk/simple string replies (nil means a RESP nil), or
+// an error for RESP error replies.
+func (c *respConn) do(args ...string) (*string, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("idempotency: redis dial: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(c.timeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return nil, fmt.Errorf("idempotency: redis write: %w", err)
+	}
+	return readRESPReply(bufio.NewReader(conn))
+}
+
+func readRESPReply(r *bufio.Reader) (*string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("idempotency: redis: empty reply")
+	}
+	switch line[0] {
+	case '+': // simple string
+		s := line[1:]
+		return &s, nil
+	case '-': // error
+		return nil, fmt.Errorf("idempotency: redis: %s", line[1:])
+	case ':': // integer
+		s := line[1:]
+		return &s, nil
+	case '$': // bulk string
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("idempotency: redis: bad bulk length %q: %w", line[1:], err)
+		}
+		if n < 0 {
+			return nil, nil // RESP nil
+		}
+		buf := make([]byte, n+2) // payload + trailing CRLF
+		if _, err := readFull(r, buf); err != nil {
+			return nil, err
+		}
+		s := string(buf[:n])
+		return &s, nil
+	default:
+		return nil, fmt.Errorf("idempotency: redis: unsupported reply type %q", line[0])
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("idempotency: redis read: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func readFull(r *bufio.Reader, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := r.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("idempotency: redis read: %w", err)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,50 @@
+// Package idempotency lets an HTTP handler replay the exact response it
+// gave the first time a client sent a given Idempotency-Key, instead of
+// re-running a non-idempotent operation (like deciding a loan application)
+// on every retry.
+package idempotency
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInProgress is returned by Store.Reserve when another request is
+// already being processed for the same key; callers should respond 409.
+var ErrInProgress = errors.New("idempotency: request already in progress for this key")
+
+// ErrKeyMismatch is returned by Store.Reserve when the key was previously
+// used with a different request body; callers should respond 422.
+var ErrKeyMismatch = errors.New("idempotency: key reused with a different request body")
+
+// Record is the stored outcome of a completed request, replayed verbatim on
+// retry.
+type Record struct {
+	BodyHash    string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Store persists idempotency records, keyed by the client-supplied
+// Idempotency-Key. Implementations must make Reserve atomic with respect to
+// concurrent callers using the same key.
+type Store interface {
+	// Reserve checks key against bodyHash. If a completed record already
+	// exists for key, it is returned for the caller to replay. If the
+	// existing record (completed or in-progress) was stored with a
+	// different bodyHash, Reserve returns ErrKeyMismatch. If another
+	// request currently holds the key, Reserve returns ErrInProgress.
+	// Otherwise, key is reserved as in-progress for ttl and Reserve returns
+	// (nil, nil), signalling the caller should do the work and call
+	// Complete or Release.
+	Reserve(key, bodyHash string, ttl time.Duration) (*Record, error)
+
+	// Complete stores the final record for key (replacing the in-progress
+	// reservation) and sets its TTL.
+	Complete(key string, rec Record, ttl time.Duration) error
+
+	// Release drops an in-progress reservation without recording a result,
+	// so a failed attempt doesn't permanently wedge the key.
+	Release(key string) error
+}
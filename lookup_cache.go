@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// lookupCache de-duplicates salary/credit lookups for a set of applications
+// that share national IDs, such as the lines of a single /apply-loan/batch
+// upload. The first caller for a given national ID does the real upstream
+// call (through the usual breaker/retry policy); concurrent and later
+// callers for the same ID block on, then reuse, that result. It is not
+// meant to live longer than one request.
+type lookupCache struct {
+	mu     sync.Mutex
+	salary map[string]*salaryCall
+	credit map[string]*creditCall
+}
+
+type salaryCall struct {
+	done chan struct{}
+	resp SalaryResponse
+	err  error
+}
+
+type creditCall struct {
+	done chan struct{}
+	resp CreditResponse
+	err  error
+}
+
+// newLookupCache returns an empty cache. A fresh one should be used per
+// incoming request (or per batch), never shared across requests.
+func newLookupCache() *lookupCache {
+	return &lookupCache{
+		salary: make(map[string]*salaryCall),
+		credit: make(map[string]*creditCall),
+	}
+}
+
+// salaryFor returns the salary lookup for nationalID, calling salaryURL at
+// most once per national ID no matter how many goroutines ask concurrently.
+func (c *lookupCache) salaryFor(ctx context.Context, nationalID, salaryURL, reqID string) (SalaryResponse, error) {
+	c.mu.Lock()
+	if call, ok := c.salary[nationalID]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.resp, call.err
+	}
+	call := &salaryCall{done: make(chan struct{})}
+	c.salary[nationalID] = call
+	c.mu.Unlock()
+
+	call.err = callWithBreaker(ctx, "salary", salaryURL, func(ctx context.Context) error {
+		s, err := callSalaryAPI(ctx, salaryURL, nationalID, reqID)
+		if err == nil {
+			call.resp = s
+		}
+		return err
+	})
+	close(call.done)
+	return call.resp, call.err
+}
+
+// creditFor returns the credit lookup for nationalID, calling creditURL at
+// most once per national ID no matter how many goroutines ask concurrently.
+func (c *lookupCache) creditFor(ctx context.Context, nationalID, creditURL, reqID string) (CreditResponse, error) {
+	c.mu.Lock()
+	if call, ok := c.credit[nationalID]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.resp, call.err
+	}
+	call := &creditCall{done: make(chan struct{})}
+	c.credit[nationalID] = call
+	c.mu.Unlock()
+
+	call.err = callWithBreaker(ctx, "credit", creditURL, func(ctx context.Context) error {
+		c, err := callCreditAPI(ctx, creditURL, nationalID, reqID)
+		if err == nil {
+			call.resp = c
+		}
+		return err
+	})
+	close(call.done)
+	return call.resp, call.err
+}
@@ -13,16 +13,23 @@ import (
 	"strconv"
 	"syscall"
 	"time"
+
+	"github.com/Thoriso27/loan-eligibility-api/applications"
+	"github.com/Thoriso27/loan-eligibility-api/breaker"
+	"github.com/Thoriso27/loan-eligibility-api/idempotency"
+	"github.com/Thoriso27/loan-eligibility-api/observability"
+	"github.com/Thoriso27/loan-eligibility-api/rules"
 )
 
 type LoanRequest struct {
-	NationalID string  `json:"national_id"`
-	LoanAmount float64 `json:"loan_amount"`
-	TermMonths int     `json:"term_months"`
+	NationalID  string  `json:"national_id"`
+	LoanAmount  float64 `json:"loan_amount"`
+	TermMonths  int     `json:"term_months"`
+	CallbackURL string  `json:"callback_url,omitempty"`
 }
 
 type LoanResponse struct {
-	Status          string          `json:"status"` // APPROVED or DECLINED
+	Status          string          `json:"status"` // APPROVED, DECLINED, or (batch and async callback only) ERROR
 	Reason          string          `json:"reason,omitempty"`
 	Reasons         []string        `json:"reasons,omitempty"`
 	MonthlyPayment  float64         `json:"monthly_payment,omitempty"`
@@ -30,6 +37,9 @@ type LoanResponse struct {
 	SalaryEcho      *SalaryResponse `json:"salary,omitempty"`
 	CreditEcho      *CreditResponse `json:"credit,omitempty"`
 	ApplicationEcho *LoanRequest    `json:"application,omitempty"`
+	Decision        *rules.Decision `json:"decision,omitempty"`
+	Error           string          `json:"error,omitempty"`          // set when Status is ERROR, except for batch lines that fail validation (Reason holds the problem)
+	ApplicationID   string          `json:"application_id,omitempty"` // set for async (callback_url) submissions
 }
 
 type SalaryResponse struct {
@@ -46,7 +56,45 @@ type CreditResponse struct {
 
 var httpClient = &http.Client{Timeout: 5 * time.Second}
 
-// ---- helpers: finance, retry, request id ----
+// Per-upstream breakers, keyed by base URL. Trips after 5 consecutive
+// failures, probes again after a 10s cooldown. There is no time window:
+// failures keep counting, however far apart, until a success resets them.
+var breakers = breaker.NewRegistry(5, 10*time.Second)
+
+// activeScorecard holds the decision-rule scorecard loaded at startup from
+// RULES_FILE (or the built-in default if that env var is unset). It's
+// read-only after main() sets it, so no locking is needed.
+var activeScorecard *rules.Scorecard
+
+// metrics and tracer are set once in main() and read-only afterwards.
+var metrics *observability.Metrics
+var tracer *observability.Tracer
+
+// retryPolicy is shared by the salary and credit calls: up to 3 attempts
+// with full-jitter exponential backoff starting at 100ms, hedging a second
+// attempt if the first hasn't returned within 400ms.
+var retryPolicy = policy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	HedgeDelay:  400 * time.Millisecond,
+}
+
+// idempotencyStore backs the Idempotency-Key handling on /apply-loan. Set in
+// main() from IDEMPOTENCY_STORE/REDIS_ADDR; read-only afterwards.
+var idempotencyStore idempotency.Store
+
+// loanRequestNationalID is passed to idempotency.Middleware so the stored
+// hash also covers the applicant identity, not just the raw bytes.
+func loanRequestNationalID(body []byte) string {
+	var req LoanRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.NationalID
+}
+
+// ---- helpers: finance, request id ----
 
 func amortizedMonthlyPayment(loanAmount float64, termMonths int, annualInterestPercent float64) float64 {
 	if termMonths <= 0 || loanAmount <= 0 {
@@ -64,35 +112,6 @@ func amortizedMonthlyPayment(loanAmount float64, termMonths int, annualInterestP
 	return math.Round(payment*100) / 100
 }
 
-// Non-generic retry helpers for older Go versions
-func withRetrySalary(attempts int, sleep time.Duration, fn func() (SalaryResponse, error)) (SalaryResponse, error) {
-	var zero SalaryResponse
-	var err error
-	for i := 0; i < attempts; i++ {
-		var v SalaryResponse
-		v, err = fn()
-		if err == nil {
-			return v, nil
-		}
-		time.Sleep(sleep)
-	}
-	return zero, err
-}
-
-func withRetryCredit(attempts int, sleep time.Duration, fn func() (CreditResponse, error)) (CreditResponse, error) {
-	var zero CreditResponse
-	var err error
-	for i := 0; i < attempts; i++ {
-		var v CreditResponse
-		v, err = fn()
-		if err == nil {
-			return v, nil
-		}
-		time.Sleep(sleep)
-	}
-	return zero, err
-}
-
 func getOrCreateReqID(r *http.Request) string {
 	id := r.Header.Get("X-Request-ID")
 	if id == "" {
@@ -110,11 +129,28 @@ func (e *httpError) Error() string {
 	return "http error: " + strconv.Itoa(e.StatusCode) + " - " + e.Body
 }
 
+// writeBreakerOpen writes a 503 with Retry-After for a dependency whose
+// circuit breaker is currently open, instead of continuing to hammer it.
+func writeBreakerOpen(w http.ResponseWriter, reqID, dependency string, retryAfter time.Duration) {
+	seconds := int(retryAfter.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":      "breaker_open",
+		"message":    dependency + " dependency is temporarily unavailable",
+		"request_id": reqID,
+	})
+}
+
 // ---- external calls ----
 
-func callSalaryAPI(baseURL, nationalID, reqID string) (SalaryResponse, error) {
+func callSalaryAPI(ctx context.Context, baseURL, nationalID, reqID string) (SalaryResponse, error) {
 	body, _ := json.Marshal(map[string]string{"national_id": nationalID})
-	req, _ := http.NewRequest(http.MethodPost, baseURL+"/verify-salary", bytes.NewBuffer(body))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/verify-salary", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Request-ID", reqID)
 	res, err := httpClient.Do(req)
@@ -133,9 +169,9 @@ func callSalaryAPI(baseURL, nationalID, reqID string) (SalaryResponse, error) {
 	return sr, nil
 }
 
-func callCreditAPI(baseURL, nationalID, reqID string) (CreditResponse, error) {
+func callCreditAPI(ctx context.Context, baseURL, nationalID, reqID string) (CreditResponse, error) {
 	body, _ := json.Marshal(map[string]string{"national_id": nationalID})
-	req, _ := http.NewRequest(http.MethodPost, baseURL+"/check-credit", bytes.NewBuffer(body))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/check-credit", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Request-ID", reqID)
 	res, err := httpClient.Do(req)
@@ -154,6 +190,27 @@ func callCreditAPI(baseURL, nationalID, reqID string) (CreditResponse, error) {
 	return cr, nil
 }
 
+// callWithBreaker looks up the breaker for baseURL and, if it allows the
+// call, runs call under the shared retry/hedge policy, recording the
+// outcome back on the breaker and the call's duration under dependency
+// (e.g. "salary", "credit") for upstream_call_duration_seconds.
+func callWithBreaker(ctx context.Context, dependency, baseURL string, call func(ctx context.Context) error) error {
+	start := time.Now()
+	defer func() { metrics.ObserveUpstreamCall(dependency, time.Since(start)) }()
+
+	b := breakers.Get(baseURL)
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := doWithPolicy(ctx, retryPolicy, call)
+	if err != nil {
+		b.RecordFailure()
+		return err
+	}
+	b.RecordSuccess()
+	return nil
+}
+
 // ---- HTTP handler ----
 
 func loanHandler(w http.ResponseWriter, r *http.Request) {
@@ -170,19 +227,13 @@ func loanHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if request.NationalID == "" || request.LoanAmount <= 0 || request.TermMonths <= 0 {
-		http.Error(w, "Missing or invalid fields", http.StatusBadRequest)
+	if msg := validateLoanRequest(request); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
 	salaryURL := os.Getenv("SALARY_API_URL")
 	creditURL := os.Getenv("CREDIT_API_URL")
-	annualRate := 20.0 // default
-	if v := os.Getenv("ANNUAL_INTEREST_PERCENT"); v != "" {
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			annualRate = f
-		}
-	}
 	if salaryURL == "" || creditURL == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -194,125 +245,75 @@ func loanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Salary call with retrySalary
-	log.Printf("req_id=%s calling salary %s national_id=%s", reqID, salaryURL, request.NationalID)
-	salary, err := withRetrySalary(3, 250*time.Millisecond, func() (SalaryResponse, error) {
-		return callSalaryAPI(salaryURL, request.NationalID, reqID)
-	})
-
-	if err != nil {
-		// If the error is a 404 from salary, treat as a business decline (domain choice).
-		if httpErr, ok := err.(*httpError); ok && httpErr.StatusCode == http.StatusNotFound {
-			monthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, annualRate)
-			reasons := []string{"Salary record not found"}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(LoanResponse{
-				Status:          "DECLINED",
-				Reason:          reasons[0],
-				Reasons:         reasons,
-				MonthlyPayment:  monthly,
-				AnnualInterest:  annualRate,
-				SalaryEcho:      nil, // unknown
-				CreditEcho:      nil,
-				ApplicationEcho: &request,
-			})
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadGateway)
-		_ = json.NewEncoder(w).Encode(map[string]string{
-			"error":      "salary_service_unavailable",
-			"message":    "Failed to verify salary",
-			"detail":     err.Error(),
-			"request_id": reqID,
-		})
+	if request.CallbackURL != "" {
+		acceptAsync(w, request, reqID, salaryURL, creditURL)
 		return
 	}
 
-	// Credit call with retry
-
-	log.Printf("req_id=%s calling credit %s national_id=%s", reqID, creditURL, request.NationalID)
-	credit, err := withRetryCredit(3, 250*time.Millisecond, func() (CreditResponse, error) {
-		return callCreditAPI(creditURL, request.NationalID, reqID)
-	})
-
-	if err != nil {
-		// Treat credit 404 as business decline (no bureau record)
-		if httpErr, ok := err.(*httpError); ok && httpErr.StatusCode == http.StatusNotFound {
-			monthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, annualRate)
-			reasons := []string{"Credit record not found"}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(LoanResponse{
-				Status:          "DECLINED",
-				Reason:          reasons[0],
-				Reasons:         reasons,
-				MonthlyPayment:  monthly,
-				AnnualInterest:  annualRate,
-				SalaryEcho:      &salary, // salary known
-				CreditEcho:      nil,
-				ApplicationEcho: &request,
-			})
-			return
-		}
+	outcome := decideLoan(r.Context(), request, reqID, salaryURL, creditURL, newLookupCache())
 
+	switch outcome.StatusCode {
+	case http.StatusServiceUnavailable:
+		writeBreakerOpen(w, reqID, outcome.BreakerDep, breakers.Get(depURL(outcome.BreakerDep, salaryURL, creditURL)).RetryAfter())
+	case http.StatusBadGateway:
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadGateway)
 		_ = json.NewEncoder(w).Encode(map[string]string{
-			"error":      "credit_service_unavailable",
-			"message":    "Failed to verify credit",
-			"detail":     err.Error(),
+			"error":      outcome.ErrKind,
+			"message":    "Failed to verify " + outcome.BreakerDep,
+			"detail":     outcome.Err.Error(),
 			"request_id": reqID,
 		})
-		return
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(outcome.Response)
 	}
+}
 
-	// Decision rules
-	monthly := amortizedMonthlyPayment(request.LoanAmount, request.TermMonths, annualRate)
-	reasons := []string{}
-	if salary.MonthlySalary < 3*monthly {
-		reasons = append(reasons, "Monthly salary is less than 3x the amortized monthly repayment")
-	}
-	if credit.CreditScore < 600 {
-		reasons = append(reasons, "Credit score below 600")
+// depURL maps a decisionOutcome.BreakerDep label back to the base URL the
+// breaker for that dependency is keyed by.
+func depURL(dep, salaryURL, creditURL string) string {
+	if dep == "credit" {
+		return creditURL
 	}
-	if credit.ActiveDefaults > 0 {
-		reasons = append(reasons, "Active defaults present")
+	return salaryURL
+}
+
+func main() {
+	defaultAnnualRate := 20.0
+	if v := os.Getenv("ANNUAL_INTEREST_PERCENT"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			defaultAnnualRate = f
+		}
 	}
-	if credit.ActiveLoans > 3 {
-		reasons = append(reasons, "More than 3 active loans")
+	scorecard, err := rules.LoadOrDefault(os.Getenv("RULES_FILE"), defaultAnnualRate)
+	if err != nil {
+		log.Fatalf("failed to load scorecard: %v", err)
 	}
+	activeScorecard = scorecard
+	log.Printf("loaded scorecard version=%s rules=%d risk_bands=%d", scorecard.Version, len(scorecard.Rules), len(scorecard.RiskBands))
 
-	w.Header().Set("Content-Type", "application/json")
-	if len(reasons) > 0 {
-		log.Printf("req_id=%s declined id=%s reasons=%v monthly=%v", reqID, request.NationalID, reasons, monthly)
-		_ = json.NewEncoder(w).Encode(LoanResponse{
-			Status:          "DECLINED",
-			Reason:          reasons[0],
-			Reasons:         reasons,
-			MonthlyPayment:  monthly,
-			AnnualInterest:  annualRate,
-			SalaryEcho:      &salary,
-			CreditEcho:      &credit,
-			ApplicationEcho: &request,
-		})
-		return
+	idempotencyStore = idempotency.NewStoreFromEnv("eligibility")
+
+	store, err := applications.NewStoreFromEnv()
+	if err != nil {
+		log.Fatalf("failed to open applications store: %v", err)
 	}
+	applicationStore = store
 
-	log.Printf("req_id=%s approved id=%s monthly=%v", reqID, request.NationalID, monthly)
-	_ = json.NewEncoder(w).Encode(LoanResponse{
-		Status:          "APPROVED",
-		MonthlyPayment:  monthly,
-		AnnualInterest:  annualRate,
-		SalaryEcho:      &salary,
-		CreditEcho:      &credit,
-		ApplicationEcho: &request,
-	})
-}
+	metrics = observability.NewMetrics("eligibility")
+	tracer = observability.NewTracer("eligibility")
+	httpClient = tracer.InstrumentClient(httpClient)
+
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	go runWebhookDispatcher(dispatcherCtx)
 
-func main() {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/apply-loan", loanHandler)
+	mux.HandleFunc("/apply-loan", metrics.Middleware("/apply-loan",
+		tracer.Middleware("/apply-loan", idempotency.Middleware(idempotencyStore, loanRequestNationalID, loanHandler))))
+	mux.HandleFunc("/apply-loan/batch", metrics.Middleware("/apply-loan/batch", tracer.Middleware("/apply-loan/batch", batchHandler)))
+	mux.HandleFunc("/applications/", metrics.Middleware("/applications/", tracer.Middleware("/applications/", applicationStatusHandler)))
+	mux.HandleFunc("/metrics", metrics.Handler())
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -339,6 +340,7 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
+	stopDispatcher()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
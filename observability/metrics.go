@@ -0,0 +1,287 @@
+// Package observability gives each of the three services (eligibility,
+// salary, credit) a shared way to report metrics and propagate a trace
+// across the HTTP calls between them. There's no vendored Prometheus client
+// or OpenTelemetry SDK in this tree, so the metrics half is a small,
+// dependency-free counter/histogram registry that renders the Prometheus
+// text exposition format directly, and the tracing half (trace.go)
+// implements just enough of the W3C Trace Context spec to thread a trace
+// through the eligibility -> salary/credit calls.
+package observability
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds (in seconds) used for every histogram
+// this package exposes, matching Prometheus's own default buckets.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics holds the fixed set of series this service reports:
+// http_requests_total{service,route,status}, http_request_duration_seconds{service,route},
+// upstream_call_duration_seconds{dependency}, and loan_decisions_total{outcome,reason}.
+type Metrics struct {
+	service string
+
+	httpRequests     *counterVec
+	httpDuration     *histogramVec
+	upstreamDuration *histogramVec
+	loanDecisions    *counterVec
+}
+
+// NewMetrics returns an empty registry for service (e.g. "eligibility",
+// "salary", "credit"); that name is baked into every http_requests_total and
+// http_request_duration_seconds series it reports.
+func NewMetrics(service string) *Metrics {
+	return &Metrics{
+		service:          service,
+		httpRequests:     newCounterVec("http_requests_total", "Total HTTP requests.", "service", "route", "status"),
+		httpDuration:     newHistogramVec("http_request_duration_seconds", "HTTP request latency in seconds.", "service", "route"),
+		upstreamDuration: newHistogramVec("upstream_call_duration_seconds", "Upstream dependency call latency in seconds.", "dependency"),
+		loanDecisions:    newCounterVec("loan_decisions_total", "Loan decisions made, by outcome and reason.", "outcome", "reason"),
+	}
+}
+
+// Middleware wraps next so every call is counted and timed under route (the
+// registered mux pattern, not the raw path, to keep the label cardinality
+// fixed).
+func (m *Metrics) Middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		m.httpRequests.inc(m.service, route, strconv.Itoa(rec.status))
+		m.httpDuration.observe(time.Since(start).Seconds(), m.service, route)
+	}
+}
+
+// ObserveUpstreamCall records how long a call to dependency ("salary" or
+// "credit") took, regardless of whether it ultimately succeeded.
+func (m *Metrics) ObserveUpstreamCall(dependency string, d time.Duration) {
+	m.upstreamDuration.observe(d.Seconds(), dependency)
+}
+
+// RecordDecision records one loan decision. outcome is "approved" or
+// "declined"; reason is the fired rule ID for a decline, the risk tier for
+// an approval, or a short code like "salary_not_found" when the decision
+// was made without a scorecard evaluation.
+func (m *Metrics) RecordDecision(outcome, reason string) {
+	m.loanDecisions.inc(outcome, reason)
+}
+
+// Handler serves the Prometheus text exposition format for every series
+// this registry tracks.
+func (m *Metrics) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		m.httpRequests.write(&b)
+		m.httpDuration.write(&b)
+		m.upstreamDuration.write(&b)
+		m.loanDecisions.write(&b)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(b.String()))
+	}
+}
+
+// statusRecorder captures the status code a handler wrote so Middleware can
+// label http_requests_total with it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush passes through to the wrapped ResponseWriter when it supports
+// flushing, so streaming handlers keep working behind Middleware.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+const labelSep = "\x1f"
+
+// counterVec is a minimal stand-in for prometheus.CounterVec: a float64 per
+// distinct tuple of label values.
+type counterVec struct {
+	mu         sync.Mutex
+	name, help string
+	labelNames []string
+	values     map[string]float64
+	labels     map[string][]string
+}
+
+func newCounterVec(name, help string, labelNames ...string) *counterVec {
+	return &counterVec{
+		name: name, help: help, labelNames: labelNames,
+		values: make(map[string]float64),
+		labels: make(map[string][]string),
+	}
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	key := strings.Join(labelValues, labelSep)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key]++
+	c.labels[key] = labelValues
+}
+
+func (c *counterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.values) == 0 {
+		return
+	}
+	writeHeader(b, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		b.WriteString(c.name)
+		writeLabels(b, c.labelNames, c.labels[key])
+		b.WriteByte(' ')
+		b.WriteString(formatFloat(c.values[key]))
+		b.WriteByte('\n')
+	}
+}
+
+// histogramVec is a minimal stand-in for prometheus.HistogramVec: cumulative
+// bucket counts plus sum/count, per distinct tuple of label values.
+type histogramVec struct {
+	mu         sync.Mutex
+	name, help string
+	labelNames []string
+	buckets    map[string][]uint64 // cumulative counts, parallel to latencyBuckets, plus a +Inf slot
+	sums       map[string]float64
+	counts     map[string]uint64
+	labels     map[string][]string
+}
+
+func newHistogramVec(name, help string, labelNames ...string) *histogramVec {
+	return &histogramVec{
+		name: name, help: help, labelNames: labelNames,
+		buckets: make(map[string][]uint64),
+		sums:    make(map[string]float64),
+		counts:  make(map[string]uint64),
+		labels:  make(map[string][]string),
+	}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, labelSep)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buckets, ok := h.buckets[key]
+	if !ok {
+		buckets = make([]uint64, len(latencyBuckets)+1) // +1 for the +Inf bucket
+		h.buckets[key] = buckets
+		h.labels[key] = labelValues
+	}
+	for i, upperBound := range latencyBuckets {
+		if v <= upperBound {
+			buckets[i]++
+		}
+	}
+	buckets[len(latencyBuckets)]++ // +Inf always matches
+	h.sums[key] += v
+	h.counts[key]++
+}
+
+func (h *histogramVec) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.buckets) == 0 {
+		return
+	}
+	writeHeader(b, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.sums) {
+		labels := h.labels[key]
+		buckets := h.buckets[key]
+		for i, upperBound := range latencyBuckets {
+			b.WriteString(h.name)
+			b.WriteString("_bucket")
+			writeLabels(b, append(append([]string{}, h.labelNames...), "le"), append(append([]string{}, labels...), formatFloat(upperBound)))
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatUint(buckets[i], 10))
+			b.WriteByte('\n')
+		}
+		b.WriteString(h.name)
+		b.WriteString("_bucket")
+		writeLabels(b, append(append([]string{}, h.labelNames...), "le"), append(append([]string{}, labels...), "+Inf"))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatUint(buckets[len(latencyBuckets)], 10))
+		b.WriteByte('\n')
+
+		b.WriteString(h.name)
+		b.WriteString("_sum")
+		writeLabels(b, h.labelNames, labels)
+		b.WriteByte(' ')
+		b.WriteString(formatFloat(h.sums[key]))
+		b.WriteByte('\n')
+
+		b.WriteString(h.name)
+		b.WriteString("_count")
+		writeLabels(b, h.labelNames, labels)
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatUint(h.counts[key], 10))
+		b.WriteByte('\n')
+	}
+}
+
+func writeHeader(b *strings.Builder, name, help, typ string) {
+	b.WriteString("# HELP ")
+	b.WriteString(name)
+	b.WriteByte(' ')
+	b.WriteString(help)
+	b.WriteByte('\n')
+	b.WriteString("# TYPE ")
+	b.WriteString(name)
+	b.WriteByte(' ')
+	b.WriteString(typ)
+	b.WriteByte('\n')
+}
+
+func writeLabels(b *strings.Builder, names, values []string) {
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(strings.NewReplacer(`"`, `\"`, `\`, `\\`).Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
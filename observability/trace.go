@@ -0,0 +1,143 @@
+package observability
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// traceparentHeader is the W3C Trace Context header name: "00-<32 hex trace
+// id>-<16 hex span id>-<2 hex flags>".
+const traceparentHeader = "traceparent"
+
+// SpanContext identifies a position in a trace: which trace it belongs to,
+// and which span within that trace.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+func (sc SpanContext) header() string {
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-01"
+}
+
+// ParseTraceParent parses a "traceparent" header value per the W3C Trace
+// Context spec, reporting ok=false for anything it doesn't recognize rather
+// than erroring, since a missing/garbled header should just start a new trace.
+func ParseTraceParent(header string) (sc SpanContext, ok bool) {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return SpanContext{}, false
+	}
+	return SpanContext{TraceID: parts[1], SpanID: parts[2]}, true
+}
+
+func newID(bytesLen int) string {
+	b := make([]byte, bytesLen)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newTraceID() string { return newID(16) }
+func newSpanID() string  { return newID(8) }
+
+type spanContextKey struct{}
+
+// FromContext returns the span the current request is part of, if tracing
+// is enabled and a span has been attached (by Tracer.Middleware).
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+func withSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// Tracer is a minimal stand-in for an OpenTelemetry SDK: it threads a W3C
+// trace across this service's HTTP server and its outgoing calls to other
+// services, logging completed spans in place of a real OTLP exporter (this
+// tree has none vendored). It's a no-op unless OTEL_EXPORTER_OTLP_ENDPOINT is
+// set, so tracing overhead (and the log noise of "exported" spans) only
+// shows up in environments that asked for it.
+//
+// IMPORTANT: despite being gated on OTEL_EXPORTER_OTLP_ENDPOINT, this does
+// NOT export spans to that (or any) OTLP endpoint - it only logs them
+// locally. An operator who points OTEL_EXPORTER_OTLP_ENDPOINT at a collector
+// expecting spans to show up there will not see any; see NewTracer's startup
+// log line. Wiring up a real OTLP exporter is follow-up work, not something
+// this change claims to have done.
+type Tracer struct {
+	service  string
+	enabled  bool
+	endpoint string
+}
+
+// NewTracer returns a Tracer for service, gated on OTEL_EXPORTER_OTLP_ENDPOINT.
+// When enabled, it logs a startup warning that spans are only logged
+// locally and are not actually sent to endpoint, since this package has no
+// OTLP exporter.
+func NewTracer(service string) *Tracer {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	enabled := endpoint != ""
+	if enabled {
+		log.Printf("otel: WARNING tracing enabled for service=%s but no OTLP exporter is wired up; "+
+			"spans will only be logged locally, nothing will be sent to endpoint=%s", service, endpoint)
+	}
+	return &Tracer{service: service, enabled: enabled, endpoint: endpoint}
+}
+
+// Middleware extracts the inbound traceparent (or starts a new trace if
+// there isn't one), attaches a new span for this request to its context,
+// and logs the span once next returns. A no-op when tracing is disabled.
+func (t *Tracer) Middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	if !t.enabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		parent, ok := ParseTraceParent(r.Header.Get(traceparentHeader))
+		if !ok {
+			parent = SpanContext{TraceID: newTraceID()}
+		}
+		span := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID()}
+
+		start := time.Now()
+		next(w, r.WithContext(withSpanContext(r.Context(), span)))
+		log.Printf("otel: trace_id=%s span_id=%s service=%s route=%s duration=%s endpoint=%s",
+			span.TraceID, span.SpanID, t.service, route, time.Since(start), t.endpoint)
+	}
+}
+
+// InstrumentClient wraps client so outgoing requests carry the in-flight
+// span (if any) as a traceparent header, each tagged with a fresh child span
+// ID, the HTTP-client half of OTel's instrumentation. A no-op (returns
+// client unchanged) when tracing is disabled.
+func (t *Tracer) InstrumentClient(client *http.Client) *http.Client {
+	if !t.enabled {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	instrumented := *client
+	instrumented.Transport = &tracingTransport{base: base}
+	return &instrumented
+}
+
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (rt *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if parent, ok := FromContext(req.Context()); ok {
+		child := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID()}
+		req.Header.Set(traceparentHeader, child.header())
+	}
+	return rt.base.RoundTrip(req)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// policy bundles the retry/hedge behaviour for a single upstream call.
+type policy struct {
+	MaxAttempts int           // total attempts, including the first
+	BaseDelay   time.Duration // backoff base for attempt i: BaseDelay * 2^i, capped at MaxDelay
+	MaxDelay    time.Duration
+	HedgeDelay  time.Duration // if > 0, fire a second attempt after this delay and take whichever returns first
+}
+
+// doWithPolicy runs attempt with exponential backoff (full jitter) across
+// p.MaxAttempts tries, optionally hedging each try per p.HedgeDelay. attempt
+// is expected to stash its result in a variable captured by the caller's
+// closure and only return the error, mirroring how the old withRetrySalary/
+// withRetryCredit helpers worked. ctx cancellation (client disconnect,
+// shutdown) aborts in-flight attempts and any pending backoff sleep.
+func doWithPolicy(ctx context.Context, p policy, attempt func(ctx context.Context) error) error {
+	var lastErr error
+	for i := 0; i < p.MaxAttempts; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		lastErr = runHedged(ctx, p.HedgeDelay, attempt)
+		if lastErr == nil {
+			return nil
+		}
+		if i == p.MaxAttempts-1 {
+			break
+		}
+
+		select {
+		case <-time.After(fullJitterBackoff(p.BaseDelay, p.MaxDelay, i)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+// runHedged runs attempt once, and if hedgeDelay > 0 and the first attempt
+// hasn't returned by then, fires a second concurrent attempt and takes
+// whichever completes first. The loser is left to finish against the
+// cancelled context and its result discarded.
+func runHedged(ctx context.Context, hedgeDelay time.Duration, attempt func(ctx context.Context) error) error {
+	if hedgeDelay <= 0 {
+		return attempt(ctx)
+	}
+
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan error, 2)
+	go func() { results <- attempt(hedgeCtx) }()
+
+	select {
+	case err := <-results:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(hedgeDelay):
+	}
+
+	go func() { results <- attempt(hedgeCtx) }()
+	return <-results
+}
+
+// fullJitterBackoff implements the "full jitter" strategy from the AWS
+// architecture blog: a random delay in [0, min(maxDelay, base*2^attempt)).
+func fullJitterBackoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	cap := base << uint(attempt)
+	if cap <= 0 || cap > maxDelay {
+		cap = maxDelay
+	}
+	if cap <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(cap)))
+}
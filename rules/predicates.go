@@ -0,0 +1,19 @@
+package rules
+
+// checks is the registry of predicates a Rule.Check name can refer to. Each
+// predicate takes the applicant context and the rule's Param and reports
+// whether the rule fires.
+var checks = map[string]func(ctx ApplicantContext, param float64) bool{
+	"salary_multiple_lt": func(ctx ApplicantContext, param float64) bool {
+		return ctx.Salary < param*ctx.DerivedMonthlyPayment
+	},
+	"credit_score_lt": func(ctx ApplicantContext, param float64) bool {
+		return float64(ctx.Credit.Score) < param
+	},
+	"active_defaults_gt": func(ctx ApplicantContext, param float64) bool {
+		return float64(ctx.Credit.ActiveDefaults) > param
+	},
+	"active_loans_gt": func(ctx ApplicantContext, param float64) bool {
+		return float64(ctx.Credit.ActiveLoans) > param
+	},
+}
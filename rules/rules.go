@@ -0,0 +1,218 @@
+// Package rules implements the eligibility decision engine as a declarative,
+// versioned scorecard: a set of weighted rules evaluated against an
+// ApplicantContext, plus risk bands that turn the accumulated score into a
+// tier and an interest rate. The scorecard is loaded from a JSON file at
+// startup (path via the RULES_FILE env var) so it can be updated without a
+// code change, and every decision records which rules fired so it can be
+// explained after the fact.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// CreditContext mirrors the fields of the credit bureau response that rules
+// can reason about.
+type CreditContext struct {
+	Score          int
+	ActiveDefaults int
+	ActiveLoans    int
+}
+
+// ApplicationContext mirrors the fields of the incoming loan application that
+// rules can reason about.
+type ApplicationContext struct {
+	NationalID string
+	LoanAmount float64
+	TermMonths int
+}
+
+// ApplicantContext is the full set of facts a rule predicate is evaluated
+// against for a single application.
+type ApplicantContext struct {
+	Salary                float64
+	Credit                CreditContext
+	Application           ApplicationContext
+	DerivedMonthlyPayment float64
+}
+
+// Rule is one line of a scorecard. Check names a predicate registered in
+// checks (see predicates.go); Param is the threshold it's evaluated against.
+// A HardFail rule that fires declines the application outright. The Weight
+// of every rule that fires, HardFail or not, is added to the applicant's
+// risk score.
+type Rule struct {
+	ID       string  `json:"id"`
+	Message  string  `json:"message"`
+	Weight   int     `json:"weight"`
+	HardFail bool    `json:"hard_fail"`
+	Check    string  `json:"check"`
+	Param    float64 `json:"param"`
+}
+
+// RiskBand maps a risk score ceiling to a tier name and the interest rate
+// offered at that tier. Bands are evaluated in ascending MaxRiskScore order;
+// the first band whose MaxRiskScore is greater than or equal to the
+// applicant's risk score wins, falling back to the last (highest) band.
+type RiskBand struct {
+	Name           string  `json:"name"`
+	MaxRiskScore   int     `json:"max_risk_score"`
+	AnnualInterest float64 `json:"annual_interest_percent"`
+}
+
+// Scorecard is a versioned, ordered set of rules and risk bands.
+type Scorecard struct {
+	Version   string     `json:"version"`
+	Rules     []Rule     `json:"rules"`
+	RiskBands []RiskBand `json:"risk_bands"`
+}
+
+// FiredRule records one rule that matched during evaluation, for audit.
+type FiredRule struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Weight  int    `json:"weight"`
+	Hard    bool   `json:"hard"`
+}
+
+// Decision is the explainable result of evaluating a Scorecard against an
+// ApplicantContext.
+type Decision struct {
+	ScorecardVersion string      `json:"scorecard_version"`
+	Approved         bool        `json:"approved"`
+	RiskScore        int         `json:"risk_score"`
+	RiskTier         string      `json:"risk_tier"`
+	AnnualInterest   float64     `json:"annual_interest_percent"`
+	FiredRules       []FiredRule `json:"fired_rules,omitempty"`
+}
+
+// Load reads and validates a Scorecard from a JSON file at path.
+func Load(path string) (*Scorecard, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rules: reading %s: %w", path, err)
+	}
+	var sc Scorecard
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("rules: parsing %s: %w", path, err)
+	}
+	if len(sc.RiskBands) == 0 {
+		return nil, fmt.Errorf("rules: %s defines no risk_bands", path)
+	}
+	for _, rule := range sc.Rules {
+		if _, ok := checks[rule.Check]; !ok {
+			return nil, fmt.Errorf("rules: %s: rule %q references unknown check %q", path, rule.ID, rule.Check)
+		}
+	}
+	sort.Slice(sc.RiskBands, func(i, j int) bool {
+		return sc.RiskBands[i].MaxRiskScore < sc.RiskBands[j].MaxRiskScore
+	})
+	return &sc, nil
+}
+
+// LoadOrDefault loads the scorecard at path, or returns the built-in default
+// scorecard (with defaultAnnualInterest as its single risk band's rate) if
+// path is empty. This is what lets RULES_FILE stay optional and preserves
+// the previous ANNUAL_INTEREST_PERCENT-driven behavior when unset.
+func LoadOrDefault(path string, defaultAnnualInterest float64) (*Scorecard, error) {
+	if path == "" {
+		return Default(defaultAnnualInterest), nil
+	}
+	return Load(path)
+}
+
+// Default reproduces the original hard-coded eligibility checks (salary >=
+// 3x payment, score >= 600, no active defaults, at most 3 active loans) as a
+// single-tier scorecard offering annualInterest to every applicant who
+// clears them.
+func Default(annualInterest float64) *Scorecard {
+	return &Scorecard{
+		Version: "default-v1",
+		Rules: []Rule{
+			{
+				ID:       "min_salary_multiple",
+				Message:  "Monthly salary is less than 3x the amortized monthly repayment",
+				HardFail: true,
+				Check:    "salary_multiple_lt",
+				Param:    3,
+			},
+			{
+				ID:       "min_credit_score",
+				Message:  "Credit score below 600",
+				HardFail: true,
+				Check:    "credit_score_lt",
+				Param:    600,
+			},
+			{
+				ID:       "active_defaults",
+				Message:  "Active defaults present",
+				HardFail: true,
+				Check:    "active_defaults_gt",
+				Param:    0,
+			},
+			{
+				ID:       "max_active_loans",
+				Message:  "More than 3 active loans",
+				HardFail: true,
+				Check:    "active_loans_gt",
+				Param:    3,
+			},
+		},
+		RiskBands: []RiskBand{
+			{Name: "standard", MaxRiskScore: 0, AnnualInterest: annualInterest},
+		},
+	}
+}
+
+// BaseAnnualInterest returns the rate of the lowest risk band, used as a
+// provisional rate while deriving the monthly payment before the applicant's
+// actual risk band is known.
+func (s *Scorecard) BaseAnnualInterest() float64 {
+	return s.RiskBands[0].AnnualInterest
+}
+
+// Evaluate runs every rule in the scorecard against ctx and returns an
+// explainable Decision: which rules fired, the resulting risk score and
+// tier, and whether any hard-fail rule declines the application outright.
+func (s *Scorecard) Evaluate(ctx ApplicantContext) Decision {
+	var fired []FiredRule
+	riskScore := 0
+	approved := true
+
+	for _, rule := range s.Rules {
+		check, ok := checks[rule.Check]
+		if !ok {
+			continue
+		}
+		if !check(ctx, rule.Param) {
+			continue
+		}
+		fired = append(fired, FiredRule{ID: rule.ID, Message: rule.Message, Weight: rule.Weight, Hard: rule.HardFail})
+		if rule.HardFail {
+			approved = false
+		}
+		riskScore += rule.Weight
+	}
+
+	band := s.bandFor(riskScore)
+	return Decision{
+		ScorecardVersion: s.Version,
+		Approved:         approved,
+		RiskScore:        riskScore,
+		RiskTier:         band.Name,
+		AnnualInterest:   band.AnnualInterest,
+		FiredRules:       fired,
+	}
+}
+
+func (s *Scorecard) bandFor(riskScore int) RiskBand {
+	for _, band := range s.RiskBands {
+		if riskScore <= band.MaxRiskScore {
+			return band
+		}
+	}
+	return s.RiskBands[len(s.RiskBands)-1]
+}
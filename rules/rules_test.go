@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluateApprovesCleanApplicant(t *testing.T) {
+	sc := Default(20.0)
+	ctx := ApplicantContext{
+		Salary:                30000,
+		Credit:                CreditContext{Score: 700, ActiveDefaults: 0, ActiveLoans: 1},
+		Application:           ApplicationContext{NationalID: "123", LoanAmount: 10000, TermMonths: 12},
+		DerivedMonthlyPayment: 900,
+	}
+
+	decision := sc.Evaluate(ctx)
+
+	if !decision.Approved {
+		t.Fatalf("Approved = false, want true; fired rules: %+v", decision.FiredRules)
+	}
+	if decision.RiskScore != 0 {
+		t.Fatalf("RiskScore = %d, want 0", decision.RiskScore)
+	}
+	if decision.RiskTier != "standard" {
+		t.Fatalf("RiskTier = %q, want %q", decision.RiskTier, "standard")
+	}
+	if decision.AnnualInterest != 20.0 {
+		t.Fatalf("AnnualInterest = %v, want 20.0", decision.AnnualInterest)
+	}
+}
+
+func TestEvaluateHardFailDeclines(t *testing.T) {
+	sc := Default(20.0)
+	ctx := ApplicantContext{
+		Salary:                30000,
+		Credit:                CreditContext{Score: 500, ActiveDefaults: 0, ActiveLoans: 1},
+		Application:           ApplicationContext{NationalID: "123", LoanAmount: 10000, TermMonths: 12},
+		DerivedMonthlyPayment: 900,
+	}
+
+	decision := sc.Evaluate(ctx)
+
+	if decision.Approved {
+		t.Fatal("Approved = true, want false: credit score 500 is below the 600 hard-fail threshold")
+	}
+	if len(decision.FiredRules) != 1 || decision.FiredRules[0].ID != "min_credit_score" {
+		t.Fatalf("FiredRules = %+v, want exactly [min_credit_score]", decision.FiredRules)
+	}
+}
+
+func TestEvaluateMultipleHardFailsAllFire(t *testing.T) {
+	sc := Default(20.0)
+	ctx := ApplicantContext{
+		Salary:                1000,
+		Credit:                CreditContext{Score: 400, ActiveDefaults: 2, ActiveLoans: 5},
+		Application:           ApplicationContext{NationalID: "123", LoanAmount: 10000, TermMonths: 12},
+		DerivedMonthlyPayment: 900,
+	}
+
+	decision := sc.Evaluate(ctx)
+
+	if decision.Approved {
+		t.Fatal("Approved = true, want false")
+	}
+	if len(decision.FiredRules) != 4 {
+		t.Fatalf("len(FiredRules) = %d, want 4 (all hard-fail checks should fire)", len(decision.FiredRules))
+	}
+}
+
+func TestEvaluateWeightedRulesAffectRiskBand(t *testing.T) {
+	sc := &Scorecard{
+		Version: "weighted-v1",
+		Rules: []Rule{
+			{ID: "thin_file", Message: "Few active loans on file", Weight: 15, Check: "active_loans_gt", Param: -1},
+		},
+		RiskBands: []RiskBand{
+			{Name: "standard", MaxRiskScore: 0, AnnualInterest: 15.0},
+			{Name: "elevated", MaxRiskScore: 20, AnnualInterest: 25.0},
+		},
+	}
+	ctx := ApplicantContext{
+		Salary:                30000,
+		Credit:                CreditContext{Score: 700, ActiveDefaults: 0, ActiveLoans: 1},
+		Application:           ApplicationContext{NationalID: "123", LoanAmount: 10000, TermMonths: 12},
+		DerivedMonthlyPayment: 900,
+	}
+
+	decision := sc.Evaluate(ctx)
+
+	if !decision.Approved {
+		t.Fatal("Approved = false, want true: the only fired rule is not a hard fail")
+	}
+	if decision.RiskScore != 15 {
+		t.Fatalf("RiskScore = %d, want 15", decision.RiskScore)
+	}
+	if decision.RiskTier != "elevated" {
+		t.Fatalf("RiskTier = %q, want %q: a risk score of 15 exceeds the standard band's max of 0", decision.RiskTier, "elevated")
+	}
+	if decision.AnnualInterest != 25.0 {
+		t.Fatalf("AnnualInterest = %v, want 25.0", decision.AnnualInterest)
+	}
+}
+
+func TestLoadRejectsUnknownCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scorecard.json")
+	const badScorecard = `{
+		"version": "bad-v1",
+		"rules": [{"id": "typo", "check": "does_not_exist", "hard_fail": true}],
+		"risk_bands": [{"name": "standard", "max_risk_score": 0, "annual_interest_percent": 10}]
+	}`
+	if err := os.WriteFile(path, []byte(badScorecard), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("Load() = nil error, want an error for a rule referencing an unregistered check")
+	}
+}
+
+func TestEvaluateUnknownCheckIsIgnored(t *testing.T) {
+	sc := &Scorecard{
+		Version: "bad-check-v1",
+		Rules: []Rule{
+			{ID: "typo", Weight: 100, HardFail: true, Check: "does_not_exist", Param: 0},
+		},
+		RiskBands: []RiskBand{{Name: "standard", MaxRiskScore: 0, AnnualInterest: 10.0}},
+	}
+	ctx := ApplicantContext{Salary: 30000, DerivedMonthlyPayment: 900}
+
+	decision := sc.Evaluate(ctx)
+
+	if !decision.Approved {
+		t.Fatal("Approved = false, want true: a rule referencing an unregistered check must not fire")
+	}
+	if len(decision.FiredRules) != 0 {
+		t.Fatalf("FiredRules = %+v, want none", decision.FiredRules)
+	}
+}
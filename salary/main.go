@@ -9,6 +9,9 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/Thoriso27/loan-eligibility-api/idempotency"
+	"github.com/Thoriso27/loan-eligibility-api/observability"
 )
 
 type SalaryRequest struct {
@@ -26,6 +29,16 @@ var salaryData = map[string]float64{
 	"99999999": 500000,
 }
 
+// salaryRequestNationalID is passed to idempotency.Middleware so the stored
+// hash also covers the applicant identity, not just the raw bytes.
+func salaryRequestNationalID(body []byte) string {
+	var req SalaryRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.NationalID
+}
+
 func verifySalaryHandler(w http.ResponseWriter, r *http.Request) {
 	// Echo request ID for traceability
 	reqID := r.Header.Get("X-Request-ID")
@@ -72,8 +85,14 @@ func verifySalaryHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	metrics := observability.NewMetrics("salary")
+	tracer := observability.NewTracer("salary")
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/verify-salary", verifySalaryHandler)
+	store := idempotency.NewStoreFromEnv("salary")
+	mux.HandleFunc("/verify-salary", metrics.Middleware("/verify-salary",
+		tracer.Middleware("/verify-salary", idempotency.Middleware(store, salaryRequestNationalID, verifySalaryHandler))))
+	mux.HandleFunc("/metrics", metrics.Handler())
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,78 @@
+package webhookclient
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyAcceptsValidSignature(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"application_id":"app_1","status":"approved"}`)
+	ts := time.Now().Unix()
+	header := Sign(secret, ts, body)
+
+	if err := Verify(header, secret, body, 5*time.Minute); err != nil {
+		t.Fatalf("Verify() = %v, want nil for a freshly signed header", err)
+	}
+}
+
+func TestVerifyRejectsTamperedBody(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"application_id":"app_1","status":"approved"}`)
+	ts := time.Now().Unix()
+	header := Sign(secret, ts, body)
+
+	tampered := []byte(`{"application_id":"app_1","status":"declined"}`)
+	if err := Verify(header, secret, tampered, 5*time.Minute); err != ErrSignatureMismatch {
+		t.Fatalf("Verify() = %v, want ErrSignatureMismatch for a tampered body", err)
+	}
+}
+
+func TestVerifyRejectsWrongSecret(t *testing.T) {
+	body := []byte(`{"application_id":"app_1"}`)
+	ts := time.Now().Unix()
+	header := Sign("whsec_test", ts, body)
+
+	if err := Verify(header, "whsec_other", body, 5*time.Minute); err != ErrSignatureMismatch {
+		t.Fatalf("Verify() = %v, want ErrSignatureMismatch for the wrong secret", err)
+	}
+}
+
+func TestVerifyRejectsStaleTimestamp(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"application_id":"app_1"}`)
+	old := time.Now().Add(-1 * time.Hour).Unix()
+	header := Sign(secret, old, body)
+
+	if err := Verify(header, secret, body, 5*time.Minute); err != ErrTimestampOutOfRange {
+		t.Fatalf("Verify() = %v, want ErrTimestampOutOfRange for a 1h-old timestamp with a 5m tolerance", err)
+	}
+}
+
+func TestVerifyRejectsMalformedHeader(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{}`)
+
+	cases := []string{
+		"",
+		"v1=deadbeef",
+		"t=" + strconv.FormatInt(time.Now().Unix(), 10),
+		"garbage",
+	}
+	for _, header := range cases {
+		if err := Verify(header, secret, body, 5*time.Minute); err != ErrMalformedHeader {
+			t.Fatalf("Verify(%q) = %v, want ErrMalformedHeader", header, err)
+		}
+	}
+}
+
+func TestSignIsDeterministic(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"a":1}`)
+	ts := int64(1700000000)
+
+	if Sign(secret, ts, body) != Sign(secret, ts, body) {
+		t.Fatal("Sign() produced different output for identical inputs")
+	}
+}